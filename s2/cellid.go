@@ -97,6 +97,37 @@ func (ci CellID) Advance(steps int64) CellID {
 	return CellID(uint64(ci) + (uint64(steps) << stepShift))
 }
 
+// AdvanceWrap advances or retreats the indicated number of steps along the
+// Hilbert curve at the current level, and returns the new position. Unlike
+// Advance, the position wraps from the end of the curve (face 5) back to
+// the beginning (face 0), or vice versa, rather than clamping.
+func (ci CellID) AdvanceWrap(steps int64) CellID {
+	if steps == 0 {
+		return ci
+	}
+
+	shift := uint(2*(maxLevel-ci.Level()) + 1)
+	if steps < 0 {
+		if minSteps := -int64(uint64(ci) >> shift); steps < minSteps {
+			stepWrap := int64(uint64(wrapOffset) >> shift)
+			steps %= stepWrap
+			if steps < minSteps {
+				steps += stepWrap
+			}
+		}
+	} else {
+		// Unlike Advance, we don't want to return End(level).
+		if maxSteps := int64((wrapOffset - uint64(ci)) >> shift); steps > maxSteps {
+			stepWrap := int64(uint64(wrapOffset) >> shift)
+			steps %= stepWrap
+			if steps > maxSteps {
+				steps -= stepWrap
+			}
+		}
+	}
+	return CellID(uint64(ci) + (uint64(steps) << shift))
+}
+
 // ToToken returns a hex-encoded string of the uint64 cell id, with leading
 // zeros included but trailing zeros stripped.
 func (ci CellID) ToToken() string {
@@ -359,6 +390,21 @@ func (ci CellID) Next() CellID {
 	return CellID(uint64(ci) + ci.lsb()<<1)
 }
 
+// Prev returns the previous cell along the Hilbert curve.
+func (ci CellID) Prev() CellID {
+	return CellID(uint64(ci) - ci.lsb()<<1)
+}
+
+// NextWrap returns the next cell along the Hilbert curve at this cell's
+// level, wrapping from the last face (5) back to the first (0) rather than
+// running off the end of the curve.
+func (ci CellID) NextWrap() CellID { return ci.AdvanceWrap(1) }
+
+// PrevWrap returns the previous cell along the Hilbert curve at this cell's
+// level, wrapping from the first face (0) back to the last (5) rather than
+// running off the start of the curve.
+func (ci CellID) PrevWrap() CellID { return ci.AdvanceWrap(-1) }
+
 // TODO: the methods below are not exported yet.  Settle on the entire API design
 // before doing this.  Do we want to mirror the C++ one as closely as possible?
 
@@ -0,0 +1,206 @@
+package s2
+
+import "sort"
+
+// A CellUnion is a collection of CellIDs that generally forms a region of
+// interest. The cells are generally organized so that they are
+// non-overlapping and in increasing order (see Normalize).
+type CellUnion []CellID
+
+func (cu CellUnion) Len() int           { return len(cu) }
+func (cu CellUnion) Less(i, j int) bool { return cu[i] < cu[j] }
+func (cu CellUnion) Swap(i, j int)      { cu[i], cu[j] = cu[j], cu[i] }
+
+// Normalize normalizes the CellUnion by discarding cells that are contained
+// by other cells, replacing groups of 4 sibling cells by their parent cell
+// whenever possible, and sorting all cell ids in increasing order.
+// Normalize is idempotent; if the CellUnion is already normalized, it is
+// left unchanged.
+func (cu *CellUnion) Normalize() {
+	sort.Sort(*cu)
+
+	output := make([]CellID, 0, len(*cu))
+	for _, ci := range *cu {
+		// Discard cells that are contained by the previous cell in output.
+		if len(output) > 0 && output[len(output)-1].Contains(ci) {
+			continue
+		}
+		// Discard any previous cells contained by this one.
+		for len(output) > 0 && ci.Contains(output[len(output)-1]) {
+			output = output[:len(output)-1]
+		}
+		// Check whether the last 3 elements plus ci can be collapsed into
+		// a single parent cell.
+		for len(output) >= 3 {
+			n := len(output)
+			// The four cells can only be siblings if they are all at the
+			// same level; otherwise the XOR/mask checks below can match by
+			// bit coincidence even though the cells aren't related.
+			if output[n-3].lsb() != ci.lsb() || output[n-2].lsb() != ci.lsb() || output[n-1].lsb() != ci.lsb() {
+				break
+			}
+			if (output[n-3] ^ output[n-2] ^ output[n-1]) != ci {
+				break
+			}
+			mask := ci.lsb() << 3
+			mask = -mask
+			if (output[n-3] & CellID(mask)) != (ci & CellID(mask)) || output[n-3].isFace() {
+				break
+			}
+			output = output[:n-3]
+			ci = ci.Parent(ci.Level() - 1)
+		}
+		output = append(output, ci)
+	}
+	*cu = output
+}
+
+// Denormalize replaces each cell in cu that is at a level smaller than
+// minLevel or that is not congruent to minLevel modulo levelMod by its
+// children, until the result satisfies the given constraints. The output
+// is appended to out, which is not required to be empty.
+func (cu CellUnion) Denormalize(minLevel, levelMod int, out *[]CellID) {
+	for _, id := range cu {
+		level := id.Level()
+		newLevel := level
+		if newLevel < minLevel {
+			newLevel = minLevel
+		}
+		if levelMod > 1 {
+			newLevel += (levelMod - (newLevel-minLevel)%levelMod) % levelMod
+			if newLevel > maxLevel {
+				newLevel = maxLevel
+			}
+		}
+		if newLevel == level {
+			*out = append(*out, id)
+			continue
+		}
+		end := id.ChildEndAtLevel(newLevel)
+		for ci := id.ChildBeginAtLevel(newLevel); ci != end; ci = ci.Next() {
+			*out = append(*out, ci)
+		}
+	}
+}
+
+// Contains reports whether the CellUnion contains the given cell id. The
+// CellUnion must be normalized.
+func (cu CellUnion) Contains(id CellID) bool {
+	i := sort.Search(len(cu), func(i int) bool { return cu[i] >= id })
+	if i != len(cu) && cu[i].RangeMin() <= id {
+		return true
+	}
+	return i != 0 && cu[i-1].RangeMax() >= id
+}
+
+// Intersects reports whether the CellUnion intersects the given cell id.
+// The CellUnion must be normalized.
+func (cu CellUnion) Intersects(id CellID) bool {
+	i := sort.Search(len(cu), func(i int) bool { return cu[i] >= id })
+	if i != len(cu) && cu[i].RangeMin() <= id.RangeMax() {
+		return true
+	}
+	return i != 0 && cu[i-1].RangeMax() >= id.RangeMin()
+}
+
+// Union returns the union of cu and other, as a normalized CellUnion.
+func (cu CellUnion) Union(other CellUnion) CellUnion {
+	result := make(CellUnion, 0, len(cu)+len(other))
+	result = append(result, cu...)
+	result = append(result, other...)
+	result.Normalize()
+	return result
+}
+
+// Intersection returns the intersection of cu and other, as a normalized
+// CellUnion. Both arguments must already be normalized.
+func (cu CellUnion) Intersection(other CellUnion) CellUnion {
+	var result CellUnion
+
+	x, y := cu, other
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		iMin, jMin := x[i].RangeMin(), y[j].RangeMin()
+		if iMin > jMin {
+			// Swap the two so that x[i].RangeMin() <= y[j].RangeMin().
+			x, y = y, x
+			i, j = j, i
+			iMin, jMin = jMin, iMin
+		}
+		if x[i].RangeMax() < jMin {
+			// x[i] is disjoint from all remaining cells of y.
+			i++
+			continue
+		}
+		// x[i] and y[j] overlap, so one must contain the other since both
+		// unions are normalized.
+		if x[i].Contains(y[j]) {
+			result = append(result, y[j])
+			j++
+		} else {
+			result = append(result, x[i])
+			i++
+		}
+	}
+	result.Normalize()
+	return result
+}
+
+// Difference returns the part of cu that is not covered by other, as a
+// normalized CellUnion. A cell of cu that other covers only in part is
+// replaced by those of its descendants that lie outside other. Both
+// arguments must already be normalized.
+func (cu CellUnion) Difference(other CellUnion) CellUnion {
+	var result CellUnion
+	for _, id := range cu {
+		cellUnionDifferenceInternal(id, other, &result)
+	}
+	result.Normalize()
+	return result
+}
+
+// cellUnionDifferenceInternal adds the parts of id that are not contained
+// by other to result, recursing into id's children as necessary.
+func cellUnionDifferenceInternal(id CellID, other CellUnion, result *CellUnion) {
+	if !other.Intersects(id) {
+		*result = append(*result, id)
+		return
+	}
+	if !other.Contains(id) {
+		for _, child := range id.Children() {
+			cellUnionDifferenceInternal(child, other, result)
+		}
+	}
+}
+
+// LeafCellsCovered returns the number of leaf cells (level 30 cells)
+// covered by this CellUnion, counting cells covered by more than one
+// element of the union once only.
+func (cu CellUnion) LeafCellsCovered() int64 {
+	normalized := append(CellUnion(nil), cu...)
+	normalized.Normalize()
+	var count int64
+	for _, id := range normalized {
+		count += 1 << uint64((maxLevel-id.Level())<<1)
+	}
+	return count
+}
+
+// ApproxArea returns the approximate area of this CellUnion. This method is
+// faster than ExactArea but has a larger error margin.
+func (cu CellUnion) ApproxArea() float64 {
+	var area float64
+	for _, id := range cu {
+		area += CellFromCellID(id).ApproxArea()
+	}
+	return area
+}
+
+// ExactArea returns the area of this CellUnion as accurately as possible.
+func (cu CellUnion) ExactArea() float64 {
+	var area float64
+	for _, id := range cu {
+		area += CellFromCellID(id).ExactArea()
+	}
+	return area
+}
@@ -0,0 +1,60 @@
+package s2
+
+import "testing"
+
+func TestCellUnionNormalizeCollapsesSiblings(t *testing.T) {
+	parent := CellIDFromFace(2).Parent(5)
+	children := parent.Children()
+
+	cu := CellUnion{children[0], children[1], children[2], children[3]}
+	cu.Normalize()
+
+	if len(cu) != 1 || cu[0] != parent {
+		t.Fatalf("Normalize() = %v, want [%v]", cu, parent)
+	}
+}
+
+func TestCellUnionNormalizeSortsAndDedups(t *testing.T) {
+	a := CellIDFromFace(1).Parent(3)
+	b := CellIDFromFace(4).Parent(3)
+
+	cu := CellUnion{b, a, a}
+	cu.Normalize()
+
+	if len(cu) != 2 || cu[0] != a || cu[1] != b {
+		t.Fatalf("Normalize() = %v, want [%v %v]", cu, a, b)
+	}
+}
+
+// TestCellUnionNormalizeMixedQuadrants covers a case where a fully-covered
+// quadrant sits next to sparsely-covered ones: only a partial set of
+// leaves is present, so the sibling-collapse logic must not mistake
+// unrelated cells (from different quadrants or levels) for a true group of
+// four siblings.
+func TestCellUnionNormalizeMixedQuadrants(t *testing.T) {
+	parent := CellIDFromFace(2).Parent(2)
+	quads := parent.Children()
+
+	quad0Children := quads[0].Children()
+	quad1Children := quads[1].Children()
+	quad2Children := quads[2].Children()
+	quad3Children := quads[3].Children()
+
+	var cu CellUnion
+	cu = append(cu, quad0Children[:]...) // quadrant 0: fully covered
+	cu = append(cu, quad1Children[0])    // quadrant 1: only child 0
+	cu = append(cu, quad2Children[0])    // quadrant 2: only child 0
+	cu = append(cu, quad3Children[:]...) // quadrant 3: fully covered
+
+	cu.Normalize()
+
+	want := CellUnion{quads[0], quad1Children[0], quad2Children[0], quads[3]}
+	if len(cu) != len(want) {
+		t.Fatalf("Normalize() = %v, want %v", cu, want)
+	}
+	for i := range want {
+		if cu[i] != want[i] {
+			t.Errorf("Normalize()[%d] = %v, want %v", i, cu[i], want[i])
+		}
+	}
+}
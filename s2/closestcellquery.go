@@ -0,0 +1,237 @@
+package s2
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"github.com/mkevac/gos2/s1"
+)
+
+// ClosestCellQueryResult is a single result of a ClosestCellQuery: a cell
+// belonging to the target CellUnion, together with its distance from the
+// query point.
+type ClosestCellQueryResult struct {
+	CellID   CellID
+	Distance s1.Angle
+}
+
+// ClosestCellQuery finds the cells of a CellUnion closest to a target
+// point, without requiring a full spatial index over the union.
+//
+// Typical usage:
+//
+//	q := &ClosestCellQuery{MaxResults: 5}
+//	results := q.FindClosest(cu, target)
+type ClosestCellQuery struct {
+	// MaxResults bounds the number of results returned. A value of 0 or
+	// less defaults to 1.
+	MaxResults int
+
+	// MaxDistance discards any cell farther from the target than this. The
+	// zero value means no limit is applied.
+	MaxDistance s1.Angle
+
+	// IncludeInteriors, if true, treats the target as being at distance
+	// zero from any cell of the union that contains it.
+	IncludeInteriors bool
+}
+
+// closestQueueItem is a candidate cell together with a lower bound on its
+// true distance from the query point.
+type closestQueueItem struct {
+	id       CellID
+	distance s1.Angle
+}
+
+// closestQueue is a min-heap of closestQueueItem ordered by distance, so
+// that the closest remaining candidate is always expanded first.
+type closestQueue []closestQueueItem
+
+func (q closestQueue) Len() int            { return len(q) }
+func (q closestQueue) Less(i, j int) bool  { return q[i].distance < q[j].distance }
+func (q closestQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *closestQueue) Push(x interface{}) { *q = append(*q, x.(closestQueueItem)) }
+func (q *closestQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// FindClosest returns up to q.MaxResults cells of cu closest to target, in
+// order of increasing distance, subject to q.MaxDistance if it is set.
+func (q *ClosestCellQuery) FindClosest(cu CellUnion, target Point) []ClosestCellQueryResult {
+	maxResults := q.MaxResults
+	if maxResults <= 0 {
+		maxResults = 1
+	}
+
+	members := make(map[CellID]bool, len(cu))
+	for _, id := range cu {
+		members[id] = true
+	}
+
+	var pq closestQueue
+	for face := 0; face < numFaces; face++ {
+		id := CellIDFromFace(face)
+		if cu.Intersects(id) {
+			heap.Push(&pq, closestQueueItem{id: id, distance: distanceToCellLowerBound(target, id)})
+		}
+	}
+
+	var results []ClosestCellQueryResult
+	worstAcceptable := func() s1.Angle {
+		if len(results) < maxResults {
+			return s1.Angle(math.Inf(1))
+		}
+		return results[len(results)-1].Distance
+	}
+
+	for pq.Len() > 0 {
+		item := heap.Pop(&pq).(closestQueueItem)
+		if q.MaxDistance > 0 && item.distance > q.MaxDistance {
+			break
+		}
+		if item.distance > worstAcceptable() {
+			break
+		}
+
+		if members[item.id] {
+			// item.distance is only the lower bound used to prioritize the
+			// search; compute the actual distance to the cell's region
+			// before handing a result back to the caller.
+			dist := distanceToCell(target, item.id, q.IncludeInteriors)
+			if q.MaxDistance > 0 && dist > q.MaxDistance {
+				continue
+			}
+			results = insertClosestResult(results, ClosestCellQueryResult{CellID: item.id, Distance: dist}, maxResults)
+			continue
+		}
+
+		// item.id is not itself a union member; descend into whichever
+		// children still intersect the union and could beat the current
+		// worst accepted result.
+		for _, child := range item.id.Children() {
+			if !cu.Intersects(child) {
+				continue
+			}
+			childDist := distanceToCellLowerBound(target, child)
+			if q.MaxDistance > 0 && childDist > q.MaxDistance {
+				continue
+			}
+			if childDist > worstAcceptable() {
+				continue
+			}
+			heap.Push(&pq, closestQueueItem{id: child, distance: childDist})
+		}
+	}
+
+	return results
+}
+
+// FindClosestLatLng is a convenience wrapper around FindClosest for callers
+// with a target expressed as a LatLng.
+func (q *ClosestCellQuery) FindClosestLatLng(cu CellUnion, target LatLng) []ClosestCellQueryResult {
+	return q.FindClosest(cu, PointFromLatLng(target))
+}
+
+// insertClosestResult inserts r into the sorted results slice, keeping it
+// sorted by increasing distance and truncated to maxResults entries.
+func insertClosestResult(results []ClosestCellQueryResult, r ClosestCellQueryResult, maxResults int) []ClosestCellQueryResult {
+	i := sort.Search(len(results), func(i int) bool { return results[i].Distance > r.Distance })
+	results = append(results, ClosestCellQueryResult{})
+	copy(results[i+1:], results[i:])
+	results[i] = r
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return results
+}
+
+// distanceToCellLowerBound returns a lower bound on the great-circle
+// distance from target to any point in the cell id, computed from the
+// cell's bounding cap. Because it is only a lower bound, it is safe to use
+// for best-first search pruning: the true distance to the cell is never
+// smaller than this value.
+func distanceToCellLowerBound(target Point, id CellID) s1.Angle {
+	cap := CellFromCellID(id).CapBound()
+	angle := s1.Angle(math.Acos(clampFloat64(target.Dot(cap.Center().Vector), -1, 1)))
+	bound := angle - cap.Radius()
+	if bound < 0 {
+		return 0
+	}
+	return bound
+}
+
+// distanceToCell returns the true great-circle distance from target to the
+// spherical quadrilateral covered by the cell id, by measuring target
+// against each of the cell's four edges in turn and keeping the smallest.
+// If includeInteriors is set and the cell contains target, the distance is
+// zero regardless of the distance to the boundary.
+func distanceToCell(target Point, id CellID, includeInteriors bool) s1.Angle {
+	cell := CellFromCellID(id)
+	if includeInteriors && cell.ContainsPoint(target) {
+		return 0
+	}
+
+	best := s1.Angle(math.Inf(1))
+	for k := 0; k < 4; k++ {
+		d := distanceToEdge(target, cell.Vertex(k), cell.Vertex((k+1)%4))
+		if d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// distanceToEdge returns the great-circle distance from x to the minor
+// great-circle arc between a and b.
+func distanceToEdge(x, a, b Point) s1.Angle {
+	n := a.Cross(b.Vector)
+	nNorm2 := n.Dot(n)
+	if nNorm2 == 0 {
+		// a and b are equal or antipodal; the "edge" degenerates to a
+		// point, so just measure the distance to a.
+		return angleBetween(x, a)
+	}
+
+	// The point on the great circle through a and b that is closest to x
+	// is the normalized projection of x onto that circle's plane.
+	proj := x.Sub(n.Mul(x.Dot(n) / nNorm2))
+	if proj.Dot(proj) == 0 {
+		return angleBetween(x, a)
+	}
+	closest := Point{proj.Normalize()}
+
+	// The projection only lies on the minor arc (between a and b, rather
+	// than the major arc on the other side of the sphere) if it is on the
+	// same side of both a and b as the other endpoint.
+	if a.Cross(closest.Vector).Dot(n) >= 0 && closest.Cross(b.Vector).Dot(n) >= 0 {
+		return angleBetween(x, closest)
+	}
+
+	da := angleBetween(x, a)
+	db := angleBetween(x, b)
+	if da < db {
+		return da
+	}
+	return db
+}
+
+// angleBetween returns the great-circle angle between two unit vectors.
+func angleBetween(p, q Point) s1.Angle {
+	return s1.Angle(math.Acos(clampFloat64(p.Dot(q.Vector), -1, 1)))
+}
+
+// clampFloat64 returns x constrained to the range [lo, hi].
+func clampFloat64(x, lo, hi float64) float64 {
+	if x < lo {
+		return lo
+	}
+	if x > hi {
+		return hi
+	}
+	return x
+}
@@ -0,0 +1,96 @@
+package s2
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCellIDEncodeDecodeRoundTrip(t *testing.T) {
+	for _, id := range []CellID{
+		CellIDFromFace(0),
+		CellIDFromFace(5),
+		CellIDFromFace(2).Parent(10),
+		CellIDFromFace(3).ChildBeginAtLevel(maxLevel),
+	} {
+		var buf bytes.Buffer
+		if err := id.Encode(&buf); err != nil {
+			t.Fatalf("Encode(%v) failed: %v", id, err)
+		}
+
+		var got CellID
+		if err := got.Decode(&buf); err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		if got != id {
+			t.Errorf("round trip = %v, want %v", got, id)
+		}
+	}
+}
+
+func TestCellIDDecodeRejectsUnknownVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := CellIDFromFace(1).Encode(&buf); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	encoded := buf.Bytes()
+	encoded[0] = encodingVersion + 1
+
+	var got CellID
+	if err := got.Decode(bytes.NewReader(encoded)); err == nil {
+		t.Error("Decode with unknown version succeeded, want error")
+	}
+}
+
+func TestCellUnionEncodeDecodeRoundTrip(t *testing.T) {
+	cu := CellUnion{
+		CellIDFromFace(0).Parent(3),
+		CellIDFromFace(1).Parent(8),
+		CellIDFromFace(5),
+	}
+
+	var buf bytes.Buffer
+	if err := cu.Encode(&buf); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	var got CellUnion
+	if err := got.Decode(&buf); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if len(got) != len(cu) {
+		t.Fatalf("Decode() = %v, want %v", got, cu)
+	}
+	for i := range cu {
+		if got[i] != cu[i] {
+			t.Errorf("Decode()[%d] = %v, want %v", i, got[i], cu[i])
+		}
+	}
+}
+
+func TestCellUnionDecodeRejectsUnknownVersion(t *testing.T) {
+	cu := CellUnion{CellIDFromFace(4)}
+
+	var buf bytes.Buffer
+	if err := cu.Encode(&buf); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	encoded := buf.Bytes()
+	encoded[0] = encodingVersion + 1
+
+	var got CellUnion
+	if err := got.Decode(bytes.NewReader(encoded)); err == nil {
+		t.Error("Decode with unknown version succeeded, want error")
+	}
+}
+
+func TestCellUnionDecodeRejectsImplausibleLength(t *testing.T) {
+	// A version byte followed by a varint claiming far more cells than the
+	// rest of the (empty) stream could possibly contain.
+	buf := []byte{encodingVersion, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+
+	var got CellUnion
+	if err := got.Decode(bytes.NewReader(buf)); err == nil {
+		t.Error("Decode with implausible cell count succeeded, want error")
+	}
+}
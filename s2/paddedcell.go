@@ -0,0 +1,147 @@
+package s2
+
+import (
+	"github.com/mkevac/gos2/r1"
+	"github.com/mkevac/gos2/r2"
+)
+
+// PaddedCell represents a Cell whose (u,v)-space bound has been expanded on
+// all four sides by a fixed amount of padding. It is used by algorithms
+// that repeatedly subdivide a cell and clip edges against the resulting
+// children, such as edge indexing and region covering of polylines, since
+// constructing a full Cell at every step of the recursion is too expensive.
+//
+// The padding is typically used to ensure that closed regions remain
+// closed; for example, no edge can be entirely contained in the interior of
+// a Cell's bound if that bound has been padded by at least some epsilon.
+type PaddedCell struct {
+	id          CellID
+	padding     float64
+	bound       r2.Rect
+	level       int
+	iLo, jLo    int // (i,j) coordinates of the cell's low corner, at leaf resolution
+	orientation int // Hilbert curve orientation of this cell (see faceIJOrientation)
+
+	middle    r2.Rect // lazily computed
+	middleSet bool
+}
+
+// PaddedCellFromCellID constructs a PaddedCell for the given cell id with
+// the given amount of padding added to its (u,v)-space bound on all sides.
+func PaddedCellFromCellID(id CellID, padding float64) *PaddedCell {
+	level := id.Level()
+	_, i, j, orientation := id.faceIJOrientation()
+	size := sizeIJ(level)
+	iLo := i & -size
+	jLo := j & -size
+
+	return &PaddedCell{
+		id:          id,
+		padding:     padding,
+		level:       level,
+		iLo:         iLo,
+		jLo:         jLo,
+		orientation: orientation,
+		bound:       padUVRect(ijLevelToBoundUV(iLo, jLo, level), padding),
+	}
+}
+
+// CellID returns the cell id this PaddedCell was constructed from.
+func (p *PaddedCell) CellID() CellID { return p.id }
+
+// Padding returns the padding added on all four sides of the cell's bound.
+func (p *PaddedCell) Padding() float64 { return p.padding }
+
+// Level returns the subdivision level of this cell.
+func (p *PaddedCell) Level() int { return p.level }
+
+// Bound returns the cell's (u,v)-space bound, expanded by the padding on
+// all four sides.
+func (p *PaddedCell) Bound() r2.Rect { return p.bound }
+
+// Middle returns the (u,v)-space rectangle that belongs to all four of this
+// cell's children, i.e. the intersection of their padded bounds. It is
+// computed and cached on first use.
+func (p *PaddedCell) Middle() r2.Rect {
+	if !p.middleSet {
+		// centerUV yields the cell center in (s,t)-space.
+		s, t := p.id.centerUV()
+		u, v := stToUV(s), stToUV(t)
+		p.middle = r2.Rect{
+			X: r1.Interval{Lo: u - p.padding, Hi: u + p.padding},
+			Y: r1.Interval{Lo: v - p.padding, Hi: v + p.padding},
+		}
+		p.middleSet = true
+	}
+	return p.middle
+}
+
+// ChildIJ returns the absolute (i,j) leaf-resolution coordinates of the low
+// corner of the child of this cell at the given position (0..3) along the
+// Hilbert curve.
+func (p *PaddedCell) ChildIJ(pos int) (i, j int) {
+	ij := posToIJ[p.orientation][pos]
+	size := sizeIJ(p.level + 1)
+	return p.iLo + (ij>>1)*size, p.jLo + (ij&1)*size
+}
+
+// EntryVertex returns the (u,v) point where the Hilbert curve enters this
+// cell.
+func (p *PaddedCell) EntryVertex() r2.Point {
+	// The curve enters at the (0,0) corner unless the axis directions are
+	// inverted, in which case it enters at the (1,1) corner.
+	b := ijLevelToBoundUV(p.iLo, p.jLo, p.level)
+	if p.orientation&invertMask != 0 {
+		return r2.Point{X: b.X.Hi, Y: b.Y.Hi}
+	}
+	return r2.Point{X: b.X.Lo, Y: b.Y.Lo}
+}
+
+// ExitVertex returns the (u,v) point where the Hilbert curve exits this
+// cell.
+func (p *PaddedCell) ExitVertex() r2.Point {
+	// The curve exits at the (1,0) corner unless the axes are swapped or
+	// inverted but not both, in which case it exits at the (0,1) corner.
+	b := ijLevelToBoundUV(p.iLo, p.jLo, p.level)
+	if p.orientation == 0 || p.orientation == swapMask|invertMask {
+		return r2.Point{X: b.X.Hi, Y: b.Y.Lo}
+	}
+	return r2.Point{X: b.X.Lo, Y: b.Y.Hi}
+}
+
+// ShrinkToFit returns the largest cell, considering only descendants of
+// this cell (including itself), whose unpadded bound is contained by rect.
+// Only the cells containing the leaf cell of p nearest the center of rect
+// are considered; if none of them is contained by rect, that leaf cell is
+// returned.
+func (p *PaddedCell) ShrinkToFit(rect r2.Rect) CellID {
+	u := 0.5 * (rect.X.Lo + rect.X.Hi)
+	v := 0.5 * (rect.Y.Lo + rect.Y.Hi)
+	i := stToIJ(uvToST(u))
+	j := stToIJ(uvToST(v))
+
+	// Clamp to this cell's own (i,j) range, so the result is guaranteed to
+	// be a descendant of p even if rect extends beyond p's own bound.
+	size := sizeIJ(p.level)
+	i = clamp(i, p.iLo, p.iLo+size-1)
+	j = clamp(j, p.jLo, p.jLo+size-1)
+
+	level := p.level
+	for level < maxLevel {
+		bound := ijLevelToBoundUV(i, j, level)
+		if bound.X.Lo >= rect.X.Lo && bound.X.Hi <= rect.X.Hi &&
+			bound.Y.Lo >= rect.Y.Lo && bound.Y.Hi <= rect.Y.Hi {
+			break
+		}
+		level++
+	}
+	return cellIDFromFaceIJ(p.id.Face(), i, j).Parent(level)
+}
+
+// padUVRect returns r expanded by padding on all four sides.
+func padUVRect(r r2.Rect, padding float64) r2.Rect {
+	return r2.Rect{
+		X: r1.Interval{Lo: r.X.Lo - padding, Hi: r.X.Hi + padding},
+		Y: r1.Interval{Lo: r.Y.Lo - padding, Hi: r.Y.Hi + padding},
+	}
+}
@@ -0,0 +1,277 @@
+package s2
+
+import "container/heap"
+
+// Region represents a two-dimensional region on the unit sphere.
+//
+// The purpose of this interface is to allow various geometric shapes to be
+// approximated as unions of cells (see RegionCoverer). To do this, a shape
+// needs to implement methods for testing containment and intersection with
+// an arbitrary S2 cell, as well as methods that provide a fast, conservative
+// bounding cap and bounding rectangle.
+type Region interface {
+	// CapBound returns a bounding spherical cap that contains the region.
+	// The bound may not be tight.
+	CapBound() Cap
+
+	// RectBound returns a bounding latitude-longitude rectangle that
+	// contains the region. The bound may not be tight.
+	RectBound() Rect
+
+	// ContainsCell reports whether the region completely contains the cell.
+	ContainsCell(c Cell) bool
+
+	// IntersectsCell reports whether the region intersects the cell.
+	IntersectsCell(c Cell) bool
+}
+
+// RegionCoverer allows arbitrary regions to be approximated as unions of
+// cells (CellUnion). This is useful for implementing various sorts of
+// search and intersection operations.
+//
+// Typical usage:
+//
+//	rc := &RegionCoverer{MaxLevel: 30, MaxCells: 8}
+//	covering := rc.Covering(region)
+//
+// The result is not guaranteed to be the minimal covering possible, but it
+// should be close to the best achievable under the given constraints.
+type RegionCoverer struct {
+	// MinLevel is the minimum cell level to be used in the covering. Must
+	// be no greater than MaxLevel. The default is 0.
+	MinLevel int
+
+	// MaxLevel is the maximum cell level to be used in the covering. Must
+	// be no less than MinLevel. A value of 0 defaults to the maximum cell
+	// level (30).
+	MaxLevel int
+
+	// LevelMod restricts cells in the covering to levels that are a
+	// multiple of LevelMod relative to MinLevel, and must be between 1 and
+	// 3 inclusive. A value of 0 defaults to 1 (no restriction).
+	LevelMod int
+
+	// MaxCells is a soft limit on the number of cells in the covering. A
+	// value of 0 or less defaults to 8.
+	MaxCells int
+}
+
+// candidate is a possible cell in the covering, together with the subset of
+// its children (if any) that have already been expanded.
+type candidate struct {
+	cell        Cell
+	terminal    bool // Is this candidate a terminal cell in the covering?
+	children    []*candidate
+	numChildren int
+	priority    int
+}
+
+// candidateQueue is a max-heap of candidates ordered by priority: higher
+// priority candidates are expanded first.
+type candidateQueue []*candidate
+
+func (pq candidateQueue) Len() int            { return len(pq) }
+func (pq candidateQueue) Less(i, j int) bool  { return pq[i].priority > pq[j].priority }
+func (pq candidateQueue) Swap(i, j int)       { pq[i], pq[j] = pq[j], pq[i] }
+func (pq *candidateQueue) Push(x interface{}) { *pq = append(*pq, x.(*candidate)) }
+func (pq *candidateQueue) Pop() interface{} {
+	old := *pq
+	n := len(old)
+	c := old[n-1]
+	*pq = old[:n-1]
+	return c
+}
+
+// coverer holds the state for a single covering computation.
+type coverer struct {
+	minLevel         int
+	maxLevel         int
+	levelMod         int
+	maxCells         int
+	region           Region
+	result           []CellID
+	pq               candidateQueue
+	interiorCovering bool
+}
+
+// newCoverer builds a coverer with rc's parameters, filling in defaults and
+// clamping values to their valid ranges.
+func (rc *RegionCoverer) newCoverer() *coverer {
+	maxLev := rc.MaxLevel
+	if maxLev == 0 {
+		maxLev = maxLevel
+	}
+	levelMod := rc.LevelMod
+	if levelMod == 0 {
+		levelMod = 1
+	}
+	maxCells := rc.MaxCells
+	if maxCells <= 0 {
+		maxCells = 8
+	}
+
+	c := &coverer{
+		minLevel: clamp(rc.MinLevel, 0, maxLevel),
+		maxLevel: clamp(maxLev, 0, maxLevel),
+		levelMod: clamp(levelMod, 1, 3),
+		maxCells: maxCells,
+	}
+	if c.maxLevel < c.minLevel {
+		c.maxLevel = c.minLevel
+	}
+	return c
+}
+
+// newCandidate returns a new candidate for cell, or nil if the cell does not
+// intersect the region being covered.
+func (c *coverer) newCandidate(cell Cell) *candidate {
+	if !c.region.IntersectsCell(cell) {
+		return nil
+	}
+	terminal := false
+	if cell.Level() >= c.minLevel {
+		if c.interiorCovering {
+			if c.region.ContainsCell(cell) {
+				terminal = true
+			} else if cell.Level()+c.levelMod > c.maxLevel {
+				return nil
+			}
+		} else if cell.Level()+c.levelMod > c.maxLevel || c.region.ContainsCell(cell) {
+			terminal = true
+		}
+	}
+	return &candidate{cell: cell, terminal: terminal}
+}
+
+// expandChildren populates cand's children by recursively expanding cell
+// numLevels levels deeper, skipping over any levels that are excluded by
+// levelMod. It returns the number of children found to be terminal.
+func (c *coverer) expandChildren(cand *candidate, cell Cell, numLevels int) int {
+	numLevels--
+	children, ok := cell.Children()
+	if !ok {
+		return 0
+	}
+	var numTerminals int
+	for _, child := range children {
+		if numLevels > 0 {
+			if c.region.IntersectsCell(child) {
+				numTerminals += c.expandChildren(cand, child, numLevels)
+			}
+			continue
+		}
+		if childCand := c.newCandidate(child); childCand != nil {
+			cand.children = append(cand.children, childCand)
+			cand.numChildren++
+			if childCand.terminal {
+				numTerminals++
+			}
+		}
+	}
+	return numTerminals
+}
+
+// addCandidate either adds cand to the result (if terminal) or expands its
+// children and adds it to the priority queue for later expansion.
+func (c *coverer) addCandidate(cand *candidate) {
+	if cand == nil {
+		return
+	}
+	if cand.terminal {
+		c.result = append(c.result, cand.cell.ID())
+		return
+	}
+
+	// Expand one "level group" of children at a time, honoring levelMod.
+	numLevels := c.levelMod
+	if cand.cell.Level() < c.minLevel {
+		numLevels = 1
+	}
+	numTerminals := c.expandChildren(cand, cand.cell, numLevels)
+	if cand.numChildren == 0 {
+		return
+	}
+
+	// If all the children are terminal and it isn't worth subdividing
+	// further, treat this candidate itself as terminal instead of queuing
+	// its children separately.
+	if !c.interiorCovering && numTerminals == 1<<uint(2*numLevels) &&
+		cand.cell.Level()+numLevels <= c.maxLevel {
+		cand.terminal = true
+		c.addCandidate(cand)
+		return
+	}
+
+	// Assign a priority that favors larger (lower-level) cells, and among
+	// cells of the same size, favors those whose children mostly intersect
+	// the region, since those are the cheapest to finish covering.
+	cand.priority = (c.maxLevel-cand.cell.Level())<<16 + cand.numChildren
+	heap.Push(&c.pq, cand)
+}
+
+// getCovering runs the priority-queue covering algorithm against region and
+// returns a normalized CellUnion.
+func (c *coverer) getCovering(region Region) CellUnion {
+	c.region = region
+	c.result = nil
+	c.pq = c.pq[:0]
+
+	for face := 0; face < numFaces; face++ {
+		c.addCandidate(c.newCandidate(CellFromCellID(CellIDFromFace(face))))
+	}
+
+	for c.pq.Len() > 0 {
+		cand := heap.Pop(&c.pq).(*candidate)
+
+		// If expanding this candidate's children would push us over the
+		// cell budget, keep the candidate itself instead of subdividing.
+		// An interior covering may only hold cells contained by the region,
+		// so there the candidate is dropped instead.
+		if cand.cell.Level() >= c.minLevel &&
+			len(c.result)+c.pq.Len()+cand.numChildren > c.maxCells {
+			if !c.interiorCovering {
+				cand.terminal = true
+				c.addCandidate(cand)
+			}
+			continue
+		}
+		for _, child := range cand.children {
+			c.addCandidate(child)
+		}
+	}
+
+	result := CellUnion(c.result)
+	result.Normalize()
+	// Normalize may merge four siblings into a parent whose level is below
+	// minLevel or not allowed by levelMod; expand such cells again.
+	if c.minLevel > 0 || c.levelMod > 1 {
+		var out []CellID
+		result.Denormalize(c.minLevel, c.levelMod, &out)
+		result = CellUnion(out)
+	}
+	return result
+}
+
+// Covering returns a CellUnion that covers the given region and satisfies
+// the restrictions imposed by MinLevel, MaxLevel, LevelMod, and MaxCells.
+func (rc *RegionCoverer) Covering(region Region) CellUnion {
+	c := rc.newCoverer()
+	c.interiorCovering = false
+	return c.getCovering(region)
+}
+
+// InteriorCovering returns a CellUnion that is contained within the given
+// region and satisfies the restrictions imposed by MinLevel, MaxLevel,
+// LevelMod, and MaxCells.
+func (rc *RegionCoverer) InteriorCovering(region Region) CellUnion {
+	c := rc.newCoverer()
+	c.interiorCovering = true
+	return c.getCovering(region)
+}
+
+// FastCovering returns a CellUnion that covers the given cap. It runs the
+// same algorithm as Covering with the cap as the region, so it is neither
+// faster nor less tight than Covering(cap).
+func (rc *RegionCoverer) FastCovering(cap Cap) CellUnion {
+	return rc.Covering(cap)
+}